@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the vtctldclient config file.",
+	Long: `Manage the vtctldclient config file (see --config), which lets
+operators keep per-cluster profiles instead of shell aliases.`,
+	Args: cobra.NoArgs,
+}
+
+var configViewCmd = &cobra.Command{
+	Use:                   "view",
+	Short:                 "Print the keys set in the config file, with any VTCTLDCLIENT_* env var overriding a key's value. Flag values and keys set only via env var are not shown.",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.NoArgs,
+	RunE:                  commandConfigView,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:                   "get <key>",
+	Short:                 "Print the resolved value of a single config key.",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	RunE:                  commandConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:                   "set <key> <value>",
+	Short:                 "Set a config key in the config file (see --config) and persist it to disk.",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(2),
+	RunE:                  commandConfigSet,
+}
+
+func commandConfigView(cmd *cobra.Command, args []string) error {
+	out, err := yaml.Marshal(viper.AllSettings())
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	fmt.Print(string(out))
+	return nil
+}
+
+func commandConfigGet(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	if !viper.IsSet(key) {
+		return fmt.Errorf("no value set for key %q", key)
+	}
+
+	fmt.Printf("%v\n", viper.Get(key))
+	return nil
+}
+
+func commandConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	viper.Set(key, value)
+
+	if err := viper.WriteConfig(); err != nil {
+		// No config file exists yet; fall back to the resolved default
+		// path so `config set` works even on a fresh install.
+		path := cfgFile
+		if path == "" {
+			home, homeErr := configHomeDir()
+			if homeErr != nil {
+				return fmt.Errorf("failed to determine default config path: %w", homeErr)
+			}
+			path = home
+		}
+
+		if err := viper.WriteConfigAs(path); err != nil {
+			return fmt.Errorf("failed to write config to %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(configViewCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	Root.AddCommand(configCmd)
+}
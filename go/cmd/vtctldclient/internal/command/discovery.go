@@ -0,0 +1,162 @@
+/*
+Copyright 2021 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"vitess.io/vitess/go/vt/log"
+)
+
+// roundRobinServiceConfig enables gRPC's round_robin load-balancing policy
+// across every address the resolver hands back, plus an exponential-backoff
+// retry for transient UNAVAILABLE errors (e.g. a vtctld restart) so a single
+// failed pick doesn't fail the whole command.
+const roundRobinServiceConfig = `{
+	"loadBalancingPolicy": "round_robin",
+	"methodConfig": [{
+		"name": [{}],
+		"retryPolicy": {
+			"maxAttempts": 5,
+			"initialBackoff": "0.5s",
+			"maxBackoff": "10s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
+// resolveServers expands the --server flag's values into a flat list of
+// candidate vtctld addresses, according to --server-discovery:
+//
+//   - "static" (the default): servers are already host:port addresses.
+//   - "srv": each entry is a DNS name to resolve via a SRV lookup.
+//   - "consul://<addr>/<service>": query the Consul catalog at <addr> for
+//     healthy instances of <service>.
+func resolveServers(discovery string, servers []string) ([]string, error) {
+	switch {
+	case discovery == "" || discovery == "static":
+		return servers, nil
+	case discovery == "srv":
+		return resolveServersSRV(servers)
+	case strings.HasPrefix(discovery, "consul://"):
+		return resolveServersConsul(discovery, servers)
+	default:
+		return nil, fmt.Errorf("unknown --server-discovery mode %q, must be \"static\", \"srv\", or \"consul://<addr>/<service>\"", discovery)
+	}
+}
+
+func resolveServersSRV(names []string) ([]string, error) {
+	var addrs []string
+	for _, name := range names {
+		_, srvs, err := net.LookupSRV("", "", name)
+		if err != nil {
+			return nil, fmt.Errorf("SRV lookup for %s failed: %w", name, err)
+		}
+		for _, srv := range srvs {
+			addrs = append(addrs, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))))
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("SRV lookup for %v returned no records", names)
+	}
+	return addrs, nil
+}
+
+func resolveServersConsul(discovery string, servers []string) ([]string, error) {
+	u, err := url.Parse(discovery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid consul discovery URL %q: %w", discovery, err)
+	}
+	service := strings.Trim(u.Path, "/")
+	if service == "" {
+		return nil, fmt.Errorf("consul discovery URL %q is missing a /<service> path", discovery)
+	}
+
+	cfg := consulapi.DefaultConfig()
+	if u.Host != "" {
+		cfg.Address = u.Host
+	}
+	client, err := consulapi.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul client for %s: %w", cfg.Address, err)
+	}
+
+	entries, _, err := client.Health().Service(service, "", true, nil)
+	if err != nil {
+		return nil, fmt.Errorf("consul catalog lookup for service %s failed: %w", service, err)
+	}
+
+	var addrs []string
+	for _, entry := range entries {
+		addr := entry.Service.Address
+		if addr == "" {
+			addr = entry.Node.Address
+		}
+		addrs = append(addrs, net.JoinHostPort(addr, strconv.Itoa(entry.Service.Port)))
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("consul has no healthy instances of service %s", service)
+	}
+	return addrs, nil
+}
+
+// filterHealthyServers dials each address in turn and calls the standard
+// gRPC health-checking service, dropping any address that doesn't respond
+// SERVING. It's used to keep a stale endpoint (e.g. a vtctld that's draining)
+// out of the round_robin pool before we ever send it real traffic.
+func filterHealthyServers(ctx context.Context, addrs []string, dialOpts []grpc.DialOption) ([]string, error) {
+	var healthy []string
+	for _, addr := range addrs {
+		if isServerHealthy(ctx, addr, dialOpts) {
+			healthy = append(healthy, addr)
+		} else {
+			log.Warningf("vtctldclient: %s failed health check, excluding it from the server pool", addr)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil, fmt.Errorf("none of the candidate servers %v passed the health check", addrs)
+	}
+	return healthy, nil
+}
+
+func isServerHealthy(ctx context.Context, addr string, dialOpts []grpc.DialOption) bool {
+	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(checkCtx, addr, append(append([]grpc.DialOption{}, dialOpts...), grpc.WithBlock())...)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(checkCtx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return false
+	}
+	return resp.GetStatus() == grpc_health_v1.HealthCheckResponse_SERVING
+}
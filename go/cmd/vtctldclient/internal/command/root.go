@@ -18,15 +18,39 @@ package command
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/oklog/run"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 
 	"vitess.io/vitess/go/trace"
+	"vitess.io/vitess/go/trace/opentelemetry"
+	"vitess.io/vitess/go/vt/grpcclient"
 	"vitess.io/vitess/go/vt/log"
 	"vitess.io/vitess/go/vt/vtctl/vtctldclient"
+
+	// Register the "grpc" vtctldclient protocol.
+	_ "vitess.io/vitess/go/vt/vtctl/grpcvtctldclient"
 )
 
 var (
@@ -35,8 +59,58 @@ var (
 	commandCtx    context.Context
 	commandCancel func()
 
-	server        string
-	actionTimeout time.Duration
+	// server is one or more vtctld addresses, either passed as a
+	// comma-separated list or via repeated --server flags. How they're
+	// interpreted is controlled by serverDiscovery.
+	server            []string
+	serverDiscovery   string
+	serverHealthCheck bool
+	actionTimeout     time.Duration
+
+	// TLS flags for securing the connection to the vtctld server.
+	grpcCert               string
+	grpcKey                string
+	grpcCA                 string
+	grpcServerName         string
+	grpcInsecureSkipVerify bool
+
+	// authStaticClientCreds points at a file containing a static auth
+	// credential to attach to every RPC, as used by the grpcclient
+	// static auth plugin.
+	authStaticClientCreds string
+
+	grpcKeepaliveTime    time.Duration
+	grpcKeepaliveTimeout time.Duration
+
+	// OpenTelemetry flags for exporting traces to an OTLP collector.
+	otelExporterOTLPEndpoint string
+	otelExporterOTLPProtocol string
+	otelExporterOTLPHeaders  map[string]string
+	otelExporterOTLPInsecure bool
+	otelServiceName          string
+	otelSampler              string
+	otelSamplerArg           float64
+
+	// traceFile, when set, writes a self-contained trace of the
+	// just-executed command (dial, RPC, response processing spans) to
+	// the given path for offline inspection.
+	traceFile string
+
+	// shutdownGracePeriod bounds how long PersistentPostRunE waits for the
+	// local client.Close() teardown before abandoning it and exiting
+	// anyway. It does not wait on the server: by the time PostRunE runs,
+	// any in-flight RPC has already returned, so canceling it promptly
+	// enough for the server to release a schema or shard lock is up to
+	// the subcommand, not this grace period.
+	shutdownGracePeriod time.Duration
+
+	// cfgFile is the path to the vtctldclient config file (see --config).
+	// When unset, initConfig falls back to $HOME/.vtctldclient.{yaml,json,toml}.
+	cfgFile string
+
+	tracerProvider *sdktrace.TracerProvider
+	rootSpan       oteltrace.Span
+	stopNotify     func()
 
 	// Root is the main entrypoint to the vtctldclient CLI.
 	Root = &cobra.Command{
@@ -44,30 +118,289 @@ var (
 		// command context for every command.
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
 			traceCloser = trace.StartTracing("vtctldclient")
-			if server == "" {
-				err = errors.New("please specify -server <vtctld_host:vtctld_port> to specify the vtctld server to connect to")
+			if len(server) == 0 {
+				err = errors.New("please specify -server <vtctld_host:vtctld_port>[,<vtctld_host:vtctld_port>...] to specify the vtctld server(s) to connect to")
+				log.Error(err)
+				return err
+			}
+
+			if otelExporterOTLPEndpoint != "" || traceFile != "" {
+				tracerProvider, err = opentelemetry.Init(context.Background(), opentelemetry.Config{
+					Endpoint:         otelExporterOTLPEndpoint,
+					Protocol:         otelExporterOTLPProtocol,
+					Headers:          otelExporterOTLPHeaders,
+					Insecure:         otelExporterOTLPInsecure,
+					ServiceName:      otelServiceName,
+					Sampler:          otelSampler,
+					SamplerArg:       otelSamplerArg,
+					FileExporterPath: traceFile,
+				})
+				if err != nil {
+					log.Error(err)
+					return err
+				}
+			}
+
+			var rootCtx context.Context
+			rootCtx, rootSpan = otel.Tracer("vtctldclient").Start(context.Background(), cmd.CommandPath())
+			rootSpan.SetAttributes(attribute.StringSlice("args", args))
+
+			dialCtx, dialSpan := otel.Tracer("vtctldclient").Start(rootCtx, "dial")
+			dialOpts, err := grpcDialOptions()
+			if err != nil {
+				dialSpan.End()
 				log.Error(err)
 				return err
 			}
+			dialOpts = append(dialOpts, grpc.WithDefaultServiceConfig(roundRobinServiceConfig))
 
-			client, err = vtctldclient.New("grpc", server)
+			addrs, err := resolveServers(serverDiscovery, server)
+			if err != nil {
+				dialSpan.End()
+				log.Error(err)
+				return err
+			}
+			if serverHealthCheck {
+				if addrs, err = filterHealthyServers(dialCtx, addrs, dialOpts); err != nil {
+					dialSpan.End()
+					log.Error(err)
+					return err
+				}
+			}
 
-			commandCtx, commandCancel = context.WithTimeout(context.Background(), actionTimeout)
+			client, err = vtctldclient.NewBalanced(addrs, dialOpts)
+			dialSpan.End()
+
+			// commandCtx carries rootSpan's trace context, so RPC and
+			// response-processing spans that individual subcommands
+			// start via otel.Tracer("vtctldclient").Start(commandCtx, ...)
+			// are parented under this command's root span.
+			signalCtx, stop := signal.NotifyContext(rootCtx, os.Interrupt, syscall.SIGTERM)
+			stopNotify = stop
+			commandCtx, commandCancel = context.WithTimeout(signalCtx, actionTimeout)
 			return err
 		},
 		// Similarly, PersistentPostRun cleans up the resources spawned by
-		// PersistentPreRun.
+		// PersistentPreRun. A Ctrl-C or SIGTERM cancels commandCtx via the
+		// signal context established in PersistentPreRunE, which is what
+		// interrupts a subcommand's in-flight RPC; by the time PostRunE
+		// runs, RunE has already returned, so there is no live RPC left
+		// for PostRunE itself to cancel or wait on. --shutdown-grace-period
+		// therefore bounds something narrower than "wait for the server to
+		// acknowledge cancellation": it bounds how long we wait for the
+		// local client.Close() teardown before giving up on it. A
+		// subcommand that holds a server-side lock (e.g. ApplySchema,
+		// Backup, MoveTables) must itself select on commandCtx.Done()
+		// around its RPC so a Ctrl-C actually reaches the server.
 		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
 			commandCancel()
-			err := client.Close()
+			stopNotify()
+
+			// done is closed once client.Close() returns, with the write
+			// to closeErr happening-before the close; abandoned is closed
+			// if the grace period elapses first. Closed channels (rather
+			// than value channels) let both run.Group actors below, plus
+			// the final select, observe either outcome without racing to
+			// consume a single value.
+			done := make(chan struct{})
+			var closeErr error
+			go func() {
+				closeErr = client.Close()
+				close(done)
+			}()
+			abandoned := make(chan struct{})
+
+			var g run.Group
+			g.Add(func() error {
+				select {
+				case <-done:
+				case <-abandoned:
+				}
+				return nil
+			}, func(error) {})
+			g.Add(func() error {
+				t := time.NewTimer(shutdownGracePeriod)
+				defer t.Stop()
+				select {
+				case <-t.C:
+					log.Warningf("timed out after %s waiting for the client connection to close; abandoning it and exiting anyway", shutdownGracePeriod)
+					close(abandoned)
+				case <-done:
+				}
+				return nil
+			}, func(error) {})
+			_ = g.Run()
+
 			trace.LogErrorsWhenClosing(traceCloser)
-			return err
+
+			exitCode := 0
+			var retErr error
+			select {
+			case <-done:
+				// closeErr's write happens-before close(done), so it's
+				// safe to read here.
+				retErr = closeErr
+				if closeErr != nil {
+					exitCode = 1
+				}
+			default:
+				// The grace period elapsed first: closeErr has no
+				// happens-before edge yet, so don't read it. Treat the
+				// close as abandoned rather than blocking on it further.
+				retErr = fmt.Errorf("timed out after %s waiting for the client connection to close", shutdownGracePeriod)
+				exitCode = 1
+			}
+			rootSpan.SetAttributes(attribute.Int("exit_code", exitCode))
+			rootSpan.End()
+
+			if tracerProvider != nil {
+				if shutdownErr := opentelemetry.Shutdown(context.Background(), tracerProvider); shutdownErr != nil {
+					log.Errorf("failed to flush opentelemetry tracer provider: %v", shutdownErr)
+				}
+			}
+
+			return retErr
 		},
 		TraverseChildren: true,
 	}
 )
 
 func init() {
-	Root.PersistentFlags().StringVar(&server, "server", "", "server to use for connection")
+	Root.PersistentFlags().StringSliceVar(&server, "server", nil, "server(s) to use for connection; comma-separated or repeated, interpreted per --server-discovery")
+	Root.PersistentFlags().StringVar(&serverDiscovery, "server-discovery", "static", `how to resolve --server into addresses: "static", "srv" (DNS SRV lookup), or "consul://<addr>/<service>"`)
+	Root.PersistentFlags().BoolVar(&serverHealthCheck, "server-health-check", false, "health-check resolved servers with grpc_health_v1 and exclude any that aren't serving")
 	Root.PersistentFlags().DurationVar(&actionTimeout, "action_timeout", time.Hour, "timeout for the total command")
+
+	Root.PersistentFlags().StringVar(&grpcCert, "grpc-cert", "", "client certificate to use for mutual TLS to the vtctld server")
+	Root.PersistentFlags().StringVar(&grpcKey, "grpc-key", "", "client private key to use for mutual TLS to the vtctld server")
+	Root.PersistentFlags().StringVar(&grpcCA, "grpc-ca", "", "trusted CA certificate for verifying the vtctld server")
+	Root.PersistentFlags().StringVar(&grpcServerName, "grpc-server-name", "", "server name to verify the vtctld server's certificate against, if different from the server address")
+	Root.PersistentFlags().BoolVar(&grpcInsecureSkipVerify, "grpc-insecure-skip-verify", false, "skip verification of the vtctld server's certificate chain and host name (insecure, for testing only)")
+	Root.PersistentFlags().StringVar(&authStaticClientCreds, "auth-static-client-creds", "", "path to a file containing static auth credentials, sent as per-RPC credentials on every call to the vtctld server")
+
+	Root.PersistentFlags().DurationVar(&grpcKeepaliveTime, "grpc-keepalive-time", 10*time.Second, "frequency of client-side keepalive pings to the vtctld server")
+	Root.PersistentFlags().DurationVar(&grpcKeepaliveTimeout, "grpc-keepalive-timeout", 10*time.Second, "amount of time the client waits for a keepalive ping ack before considering the connection dead")
+
+	Root.PersistentFlags().StringVar(&otelExporterOTLPEndpoint, "otel-exporter-otlp-endpoint", "", "OTLP collector endpoint to export traces to, e.g. localhost:4317 (unset disables OpenTelemetry export)")
+	Root.PersistentFlags().StringVar(&otelExporterOTLPProtocol, "otel-exporter-otlp-protocol", "grpc", "OTLP transport to use when exporting traces: grpc or http")
+	Root.PersistentFlags().StringToStringVar(&otelExporterOTLPHeaders, "otel-exporter-otlp-headers", nil, "additional headers to send with every trace export request, e.g. for collector authentication")
+	Root.PersistentFlags().BoolVar(&otelExporterOTLPInsecure, "otel-exporter-otlp-insecure", false, "disable TLS when exporting traces to the OTLP collector, e.g. for a local collector reachable in plaintext")
+	Root.PersistentFlags().StringVar(&otelServiceName, "otel-service-name", "vtctldclient", "service.name resource attribute to report to the OTLP collector")
+	Root.PersistentFlags().StringVar(&otelSampler, "otel-sampler", "always_on", "OpenTelemetry sampler to use: always_on, always_off, or parentbased_traceidratio")
+	Root.PersistentFlags().Float64Var(&otelSamplerArg, "otel-sampler-arg", 1.0, "sampling ratio in [0,1] used when --otel-sampler=parentbased_traceidratio")
+	Root.PersistentFlags().StringVar(&traceFile, "trace-file", "", "write a self-contained trace of the executed command to this file for offline inspection")
+
+	Root.PersistentFlags().DurationVar(&shutdownGracePeriod, "shutdown-grace-period", 30*time.Second, "how long to wait for the client connection to close before abandoning it and exiting anyway")
+
+	Root.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default $HOME/.vtctldclient.yaml)")
+	cobra.OnInitialize(initConfig)
+}
+
+// configHomeDir returns the default config file path, $HOME/.vtctldclient.yaml.
+func configHomeDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".vtctldclient.yaml"), nil
+}
+
+// initConfig resolves flags from a config file and/or VTCTLDCLIENT_*
+// environment variables, for any flag the user didn't pass explicitly on
+// the command line. File and env values never override an explicit flag.
+func initConfig() {
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+	} else {
+		home, err := os.UserHomeDir()
+		cobra.CheckErr(err)
+
+		viper.AddConfigPath(home)
+		viper.SetConfigName(".vtctldclient")
+	}
+
+	viper.SetEnvPrefix("VTCTLDCLIENT")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
+
+	if err := viper.ReadInConfig(); err == nil {
+		log.Infof("vtctldclient: using config file %s", viper.ConfigFileUsed())
+	}
+
+	Root.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || !viper.IsSet(f.Name) {
+			return
+		}
+
+		// Slice-valued flags (e.g. --server) need their own path: viper
+		// hands back a []interface{} for a YAML list, and "%v"-formatting
+		// that into a single string mangles it into one bogus element.
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			if err := sv.Replace(viper.GetStringSlice(f.Name)); err != nil {
+				log.Warningf("vtctldclient: failed to apply config value for %s: %v", f.Name, err)
+			}
+			return
+		}
+
+		if err := Root.PersistentFlags().Set(f.Name, fmt.Sprintf("%v", viper.Get(f.Name))); err != nil {
+			log.Warningf("vtctldclient: failed to apply config value for %s: %v", f.Name, err)
+		}
+	})
+}
+
+// grpcDialOptions builds the set of grpc.DialOptions used to connect to the
+// vtctld server, based on the persistent TLS, auth, and keepalive flags.
+func grpcDialOptions() ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+
+	tlsConfigured := grpcCert != "" || grpcKey != "" || grpcCA != "" || grpcInsecureSkipVerify
+	if tlsConfigured {
+		tlsCfg := &tls.Config{
+			ServerName:         grpcServerName,
+			InsecureSkipVerify: grpcInsecureSkipVerify,
+		}
+
+		if grpcCert != "" || grpcKey != "" {
+			cert, err := tls.LoadX509KeyPair(grpcCert, grpcKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load grpc client certificate/key: %w", err)
+			}
+			tlsCfg.Certificates = []tls.Certificate{cert}
+		}
+
+		if grpcCA != "" {
+			ca, err := os.ReadFile(grpcCA)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read grpc-ca file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("failed to parse any certificates from grpc-ca file %s", grpcCA)
+			}
+			tlsCfg.RootCAs = pool
+		}
+
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if authStaticClientCreds != "" {
+		if !tlsConfigured {
+			log.Warningf("--auth-static-client-creds is set without any --grpc-* TLS flags; the static auth credentials will be sent to the vtctld server in cleartext")
+		}
+
+		creds, err := grpcclient.StaticAuthClientCredsFromFile(authStaticClientCreds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load auth-static-client-creds file: %w", err)
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(creds))
+	}
+
+	opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:    grpcKeepaliveTime,
+		Timeout: grpcKeepaliveTimeout,
+	}))
+
+	return opts, nil
 }
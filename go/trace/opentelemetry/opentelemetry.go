@@ -0,0 +1,158 @@
+/*
+Copyright 2021 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package opentelemetry provides an OpenTelemetry-backed tracer provider,
+// exporting spans to an OTLP collector over gRPC or HTTP. It is intended
+// for callers that want a self-contained tracer provider without going
+// through one of the built-in trace plugins in the parent trace package.
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// Config controls how Init builds the OTLP exporter and resulting
+// TracerProvider.
+type Config struct {
+	// Endpoint is the OTLP collector address, e.g. "localhost:4317" for
+	// gRPC or "localhost:4318" for HTTP.
+	Endpoint string
+	// Protocol selects the OTLP transport: "grpc" (default) or "http".
+	Protocol string
+	// Headers are additional headers sent with every export request,
+	// commonly used for collector authentication.
+	Headers map[string]string
+	// Insecure disables TLS when talking to the collector.
+	Insecure bool
+	// ServiceName is recorded as the service.name resource attribute.
+	ServiceName string
+	// Sampler is one of "always_on", "always_off", or "parentbased_traceidratio".
+	Sampler string
+	// SamplerArg is the ratio used when Sampler is "parentbased_traceidratio".
+	SamplerArg float64
+	// FileExporterPath, when set, additionally writes every span as JSON
+	// to the given path, for offline inspection of a single command run.
+	FileExporterPath string
+}
+
+// Init builds the configured exporter(s) and sdktrace.TracerProvider from
+// cfg, registers the provider as the global OpenTelemetry tracer provider,
+// and returns it so the caller can flush it on shutdown via Shutdown.
+//
+// An OTLP exporter is only created when cfg.Endpoint is set; cfg.FileExporterPath
+// may be used on its own to capture a trace without a collector.
+func Init(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	var batchers []sdktrace.TracerProviderOption
+
+	if cfg.Endpoint != "" {
+		exporter, err := newExporter(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("opentelemetry: failed to create OTLP exporter: %w", err)
+		}
+		batchers = append(batchers, sdktrace.WithBatcher(exporter))
+	}
+
+	if cfg.FileExporterPath != "" {
+		fileExporter, err := newFileExporter(cfg.FileExporterPath)
+		if err != nil {
+			return nil, fmt.Errorf("opentelemetry: failed to create trace-file exporter: %w", err)
+		}
+		batchers = append(batchers, sdktrace.WithBatcher(fileExporter))
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(
+		semconv.ServiceNameKey.String(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("opentelemetry: failed to build resource: %w", err)
+	}
+
+	opts := append(batchers,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newSampler(cfg)),
+	)
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+
+	return tp, nil
+}
+
+// Shutdown flushes and shuts down tp, returning any error rather than
+// panicking, so callers can safely defer it. A nil tp is a no-op.
+func Shutdown(ctx context.Context, tp *sdktrace.TracerProvider) error {
+	if tp == nil {
+		return nil
+	}
+	return tp.Shutdown(ctx)
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Protocol {
+	case "", "grpc":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case "http":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("opentelemetry: unknown protocol %q, must be \"grpc\" or \"http\"", cfg.Protocol)
+	}
+}
+
+// newFileExporter returns a human-readable, line-delimited JSON exporter
+// that writes each exported span to path, truncating any existing file.
+func newFileExporter(path string) (sdktrace.SpanExporter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return stdouttrace.New(stdouttrace.WithWriter(f))
+}
+
+func newSampler(cfg Config) sdktrace.Sampler {
+	switch cfg.Sampler {
+	case "always_off":
+		return sdktrace.NeverSample()
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SamplerArg))
+	case "", "always_on":
+		return sdktrace.AlwaysSample()
+	default:
+		return sdktrace.AlwaysSample()
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package grpcvtctldclient registers the "grpc" vtctldclient protocol,
+// dialing either a single vtctld server or, via NewBalanced, several of
+// them behind one grpc.ClientConn.
+package grpcvtctldclient
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+
+	"vitess.io/vitess/go/vt/vtctl/vtctldclient"
+)
+
+type gRPCVtctldClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewWithDialOpts dials addr with opts and wraps the resulting connection
+// as a vtctldclient.VtctldClient.
+func NewWithDialOpts(addr string, opts []grpc.DialOption) (vtctldclient.VtctldClient, error) {
+	cc, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &gRPCVtctldClient{cc: cc}, nil
+}
+
+func (client *gRPCVtctldClient) Close() error {
+	return client.cc.Close()
+}
+
+// NewBalanced dials a vtctldclient.VtctldClient balanced across addrs. It
+// hands grpc a manual resolver pre-populated with addrs, so callers pick
+// the balancing behavior (e.g. round_robin) purely through a service
+// config DialOption; this function does not assume one.
+func NewBalanced(addrs []string, opts []grpc.DialOption) (vtctldclient.VtctldClient, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("grpcvtctldclient: NewBalanced requires at least one address")
+	}
+
+	r := manual.NewBuilderWithScheme("vtctldclient-static")
+	resolverAddrs := make([]resolver.Address, 0, len(addrs))
+	for _, addr := range addrs {
+		// ServerName makes TLS verify each server against its own
+		// address rather than the "vtctld" dial target below.
+		resolverAddrs = append(resolverAddrs, resolver.Address{Addr: addr, ServerName: addr})
+	}
+	r.InitialState(resolver.State{Addresses: resolverAddrs})
+
+	dialOpts := append([]grpc.DialOption{grpc.WithResolvers(r)}, opts...)
+	cc, err := grpc.Dial(r.Scheme()+":///vtctld", dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &gRPCVtctldClient{cc: cc}, nil
+}
+
+func init() {
+	vtctldclient.RegisterFactory("grpc", func(addr string) (vtctldclient.VtctldClient, error) {
+		// grpc.Dial rejects a dial with no transport credentials, so the
+		// default is plaintext, as with no --grpc-* TLS flags.
+		return NewWithDialOpts(addr, []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())})
+	})
+	vtctldclient.RegisterDialOptsFactory("grpc", NewWithDialOpts)
+	vtctldclient.RegisterBalancedFactory("grpc", NewBalanced)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vtctldclient defines the client interface for talking to a
+// vtctld server, plus a registry of protocol implementations (currently
+// just "grpc") that vtctldclient/internal/command dials through.
+package vtctldclient
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc"
+)
+
+// VtctldClient is the interface exposed by vtctldclient to its callers.
+type VtctldClient interface {
+	Close() error
+}
+
+// Factory creates a VtctldClient for addr using the named protocol's
+// default dial options.
+type Factory func(addr string) (VtctldClient, error)
+
+// DialOptsFactory creates a VtctldClient for addr using caller-supplied
+// grpc.DialOptions, e.g. to configure TLS or per-RPC auth credentials.
+type DialOptsFactory func(addr string, opts []grpc.DialOption) (VtctldClient, error)
+
+// BalancedFactory creates a VtctldClient balanced across multiple
+// addresses, e.g. via the grpc round_robin load-balancing policy.
+type BalancedFactory func(addrs []string, opts []grpc.DialOption) (VtctldClient, error)
+
+var (
+	factories         = make(map[string]Factory)
+	dialOptsFactories = make(map[string]DialOptsFactory)
+	balancedFactories = make(map[string]BalancedFactory)
+)
+
+// RegisterFactory registers a Factory under the given protocol name. It
+// panics if the protocol is already registered.
+func RegisterFactory(name string, factory Factory) {
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("vtctldclient.RegisterFactory: protocol %s already registered", name))
+	}
+	factories[name] = factory
+}
+
+// RegisterDialOptsFactory registers a DialOptsFactory under the given
+// protocol name. It panics if the protocol is already registered.
+func RegisterDialOptsFactory(name string, factory DialOptsFactory) {
+	if _, ok := dialOptsFactories[name]; ok {
+		panic(fmt.Sprintf("vtctldclient.RegisterDialOptsFactory: protocol %s already registered", name))
+	}
+	dialOptsFactories[name] = factory
+}
+
+// RegisterBalancedFactory registers a BalancedFactory under the given
+// protocol name. It panics if the protocol is already registered.
+func RegisterBalancedFactory(name string, factory BalancedFactory) {
+	if _, ok := balancedFactories[name]; ok {
+		panic(fmt.Sprintf("vtctldclient.RegisterBalancedFactory: protocol %s already registered", name))
+	}
+	balancedFactories[name] = factory
+}
+
+// New creates a VtctldClient for addr using protocol's default dial
+// options.
+func New(protocol, addr string) (VtctldClient, error) {
+	factory, ok := factories[protocol]
+	if !ok {
+		return nil, fmt.Errorf("vtctldclient: unregistered protocol %q", protocol)
+	}
+	return factory(addr)
+}
+
+// NewWithDialOpts creates a VtctldClient for addr using protocol, dialing
+// with the given grpc.DialOptions instead of the protocol's defaults.
+func NewWithDialOpts(protocol, addr string, opts []grpc.DialOption) (VtctldClient, error) {
+	factory, ok := dialOptsFactories[protocol]
+	if !ok {
+		return nil, fmt.Errorf("vtctldclient: protocol %q does not support custom dial options", protocol)
+	}
+	return factory(addr, opts)
+}
+
+// NewBalanced creates a VtctldClient load-balanced across addrs, dialing
+// with the given grpc.DialOptions. Only the "grpc" protocol supports
+// balancing across multiple addresses.
+func NewBalanced(addrs []string, opts []grpc.DialOption) (VtctldClient, error) {
+	factory, ok := balancedFactories["grpc"]
+	if !ok {
+		return nil, fmt.Errorf("vtctldclient: no balanced factory registered for protocol \"grpc\"")
+	}
+	return factory(addrs, opts)
+}